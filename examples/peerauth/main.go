@@ -0,0 +1,90 @@
+// Command peerauth is an example of a service that authenticates its
+// callers purely from their AWS EC2 instance identity document, with no
+// shared secret between the two sides: any instance in an allowed
+// account can reach the service, and every other caller is rejected.
+//
+// It runs both halves of that exchange in one process for the example's
+// sake, but the client half still fetches its identity document and
+// PKCS7 signature from the instance metadata service via
+// iidverify.IMDSClient, so running this example requires an actual EC2
+// instance in account 123456789012 (adjust WithExpectedAccountIDs below
+// to match yours). In reality the server would run on the peer service
+// instead, with only the client running on the calling instance.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/Herkemer/go-aws-iid-verify/httpauth"
+	"github.com/Herkemer/go-aws-iid-verify/iidverify"
+)
+
+// memoryNonceStore is a minimal iidverify.NonceStore for the example.
+// A real deployment would back this with a shared store (e.g. Redis)
+// so replay detection works across server instances.
+type memoryNonceStore struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (s *memoryNonceStore) Seen(instanceID, pendingTime string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen == nil {
+		s.seen = map[string]bool{}
+	}
+	key := instanceID + "/" + pendingTime
+	if s.seen[key] {
+		return true
+	}
+	s.seen[key] = true
+	return false
+}
+
+func main() {
+	verifier, err := iidverify.NewVerifier(
+		iidverify.WithExpectedAccountIDs("123456789012"),
+		iidverify.WithNonceStore(&memoryNonceStore{}),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	peerService := httptest.NewServer(httpauth.Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, _ := httpauth.IdentityFromContext(r.Context())
+			fmt.Fprintf(w, "hello, instance %s in account %s", id.InstanceID, id.AccountID)
+		}),
+		httpauth.WithVerifier(verifier),
+	))
+	defer peerService.Close()
+
+	imds := iidverify.NewIMDSClient()
+	pkcs7Sig, err := imds.FetchPKCS7Signature(context.Background())
+	if err != nil {
+		panic("fetching PKCS7 signature from the instance metadata service: " + err.Error())
+	}
+
+	req, err := http.NewRequest(http.MethodGet, peerService.URL, nil)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set(httpauth.PKCS7Header, string(pkcs7Sig))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("peer service responded with %s: %s\n", resp.Status, body)
+}
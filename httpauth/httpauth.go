@@ -0,0 +1,151 @@
+// Package httpauth provides HTTP middleware that authenticates inbound
+// requests using a signed AWS EC2 instance identity document, so a peer
+// service can trust a caller's AWS account, region, and instance ID
+// without either side holding a shared secret.
+package httpauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Herkemer/go-aws-iid-verify/iidverify"
+)
+
+// PKCS7Header is the request header a caller may set to its raw, base64
+// /latest/dynamic/instance-identity/pkcs7 contents.
+const PKCS7Header = "X-Amz-IID-PKCS7"
+
+// AuthorizationScheme is the Authorization header scheme Middleware
+// recognizes as an alternative to PKCS7Header: "Authorization: AWS-IID
+// <base64 pkcs7>".
+const AuthorizationScheme = "AWS-IID"
+
+type contextKey int
+
+const identityContextKey contextKey = iota
+
+// IdentityFromContext returns the identity document Middleware verified
+// for this request, if any.
+func IdentityFromContext(ctx context.Context) (*iidverify.IdentityDocument, bool) {
+	id, ok := ctx.Value(identityContextKey).(*iidverify.IdentityDocument)
+	return id, ok
+}
+
+// verifier is the subset of *iidverify.Verifier that Middleware depends
+// on, so tests can substitute a fake without exercising real signature
+// verification.
+type verifier interface {
+	VerifyPKCS7(pkcs7Blob []byte) (*iidverify.IdentityDocument, error)
+}
+
+type verifierFunc func([]byte) (*iidverify.IdentityDocument, error)
+
+func (f verifierFunc) VerifyPKCS7(pkcs7Blob []byte) (*iidverify.IdentityDocument, error) {
+	return f(pkcs7Blob)
+}
+
+type config struct {
+	verifier  verifier
+	errorFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Option configures Middleware. See WithVerifier and WithErrorHandler.
+type Option func(*config)
+
+// WithVerifier overrides the Verifier used to check the signed identity
+// document. Without this option, Middleware uses a default Verifier with
+// no region pin and no replay policy (see iidverify.NewVerifier); pass
+// one built with iidverify.WithMaxAge, iidverify.WithNonceStore, and the
+// expected-account/region options to enforce a replay policy.
+func WithVerifier(v *iidverify.Verifier) Option {
+	return func(c *config) { c.verifier = v }
+}
+
+// WithErrorHandler overrides how Middleware responds when it can't
+// authenticate a request. The default responds with a status code
+// chosen from the error (see statusFor) and the error's message as the
+// body.
+func WithErrorHandler(fn func(w http.ResponseWriter, r *http.Request, err error)) Option {
+	return func(c *config) { c.errorFunc = fn }
+}
+
+// Middleware wraps next, authenticating each request from a signed
+// instance identity document carried in the PKCS7Header or Authorization
+// header before passing it through. On success, the parsed identity
+// document is attached to the request's context and retrievable with
+// IdentityFromContext. On failure, it responds directly and does not
+// call next.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := config{
+		verifier:  verifierFunc(iidverify.VerifyPKCS7),
+		errorFunc: defaultErrorHandler,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blob, err := signedDocumentFromRequest(r)
+		if err != nil {
+			cfg.errorFunc(w, r, err)
+			return
+		}
+
+		id, err := cfg.verifier.VerifyPKCS7(blob)
+		if err != nil {
+			cfg.errorFunc(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), identityContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// errNoCredential is returned when a request carries neither of the
+// headers Middleware recognizes.
+var errNoCredential = errors.New("httpauth: no signed identity document present on request")
+
+// signedDocumentFromRequest extracts the base64 PKCS7 blob from
+// whichever of PKCS7Header or the Authorization header the request set.
+func signedDocumentFromRequest(r *http.Request) ([]byte, error) {
+	if blob := r.Header.Get(PKCS7Header); blob != "" {
+		return []byte(blob), nil
+	}
+
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, blob, found := strings.Cut(auth, " ")
+		if !found || scheme != AuthorizationScheme {
+			return nil, fmt.Errorf("httpauth: unsupported Authorization scheme %q", scheme)
+		}
+		return []byte(blob), nil
+	}
+
+	return nil, errNoCredential
+}
+
+// defaultErrorHandler responds with a status code chosen from err and
+// err's message as the body.
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	http.Error(w, err.Error(), statusFor(err))
+}
+
+// statusFor maps the sentinel errors iidverify and this package return
+// to the HTTP status code that best describes them, so a caller using
+// WithErrorHandler to customize the response body doesn't also have to
+// reimplement this mapping.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, iidverify.ErrAccountNotAllowed), errors.Is(err, iidverify.ErrRegionNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, iidverify.ErrMalformedDocument):
+		return http.StatusBadRequest
+	case errors.Is(err, errNoCredential):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusUnauthorized
+	}
+}
@@ -0,0 +1,84 @@
+package httpauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Herkemer/go-aws-iid-verify/iidverify"
+)
+
+var errVerifyFailed = errors.New("verification failed")
+
+func fakeVerifier(id *iidverify.IdentityDocument, err error) verifier {
+	return verifierFunc(func(blob []byte) (*iidverify.IdentityDocument, error) {
+		if string(blob) != "valid-blob" {
+			return nil, errVerifyFailed
+		}
+		return id, err
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	wantID := &iidverify.IdentityDocument{InstanceID: "i-0123456789abcdef0"}
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "pkcs7 header", header: PKCS7Header, value: "valid-blob", wantStatus: http.StatusOK, wantCalled: true},
+		{name: "authorization header", header: "Authorization", value: "AWS-IID valid-blob", wantStatus: http.StatusOK, wantCalled: true},
+		{name: "wrong authorization scheme", header: "Authorization", value: "Bearer valid-blob", wantStatus: http.StatusUnauthorized},
+		{name: "no credential", wantStatus: http.StatusUnauthorized},
+		{name: "bad signature", header: PKCS7Header, value: "garbage", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			var gotID *iidverify.IdentityDocument
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				gotID, _ = IdentityFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			handler := Middleware(next, withTestVerifier(fakeVerifier(wantID, nil)))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if called != tt.wantCalled {
+				t.Errorf("next called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantCalled && gotID != wantID {
+				t.Errorf("IdentityFromContext() = %v, want %v", gotID, wantID)
+			}
+		})
+	}
+}
+
+func TestIdentityFromContextMissing(t *testing.T) {
+	if _, ok := IdentityFromContext(context.Background()); ok {
+		t.Error("IdentityFromContext() ok = true on a plain context, want false")
+	}
+}
+
+// withTestVerifier installs v directly, bypassing the *iidverify.Verifier
+// type WithVerifier requires, so tests don't need a real signed document.
+func withTestVerifier(v verifier) Option {
+	return func(c *config) { c.verifier = v }
+}
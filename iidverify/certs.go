@@ -0,0 +1,210 @@
+package iidverify
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// Partition identifies an AWS partition. Regions within a partition share
+// the same instance identity document signing certificates.
+//
+// AWS's opt-in regions (e.g. ap-east-1, me-south-1, af-south-1,
+// eu-south-1, ap-southeast-3) publish their own certificates distinct
+// from the rest of the commercial partition, but this package does not
+// yet bundle them: documents from those regions are verified against the
+// commercial certificate below and will fail unless AWS happens to have
+// reused it. CertificateForRegion only covers PartitionAWS, PartitionAWSCN,
+// and PartitionAWSUSGov today.
+type Partition string
+
+// These are the partitions AWS publishes IID signing certificates for.
+const (
+	PartitionAWS      Partition = "aws"
+	PartitionAWSCN    Partition = "aws-cn"
+	PartitionAWSUSGov Partition = "aws-us-gov"
+)
+
+// awsRSAIIDCert is AWS's RSA public certificate used to sign the plain
+// (non-PKCS7) instance identity document signature in the commercial
+// ("aws") partition.
+const awsRSAIIDCert = `-----BEGIN CERTIFICATE-----
+MIIDIjCCAougAwIBAgIJAKnL4UEDMN/FMA0GCSqGSIb3DQEBBQUAMGoxCzAJBgNV
+BAYTAlVTMRMwEQYDVQQIEwpXYXNoaW5ndG9uMRAwDgYDVQQHEwdTZWF0dGxlMRgw
+FgYDVQQKEw9BbWF6b24uY29tIEluYy4xGjAYBgNVBAMTEWVjMi5hbWF6b25hd3Mu
+Y29tMB4XDTE0MDYwNTE0MjgwMloXDTI0MDYwNTE0MjgwMlowajELMAkGA1UEBhMC
+VVMxEzARBgNVBAgTCldhc2hpbmd0b24xEDAOBgNVBAcTB1NlYXR0bGUxGDAWBgNV
+BAoTD0FtYXpvbi5jb20gSW5jLjEaMBgGA1UEAxMRZWMyLmFtYXpvbmF3cy5jb20w
+gZ8wDQYJKoZIhvcNAQEBBQADgY0AMIGJAoGBAIe9GN//SRK2knbjySG0ho3yqQM3
+e2TDhWO8D2e8+XZqck754gFSo99AbT2RmXClambI7xsYHZFapbELC4H91ycihvrD
+jbST1ZjkLQgga0NE1q43eS68ZeTDccScXQSNivSlzJZS8HJZjgqzBlXjZftjtdJL
+XeE4hwvo0sD4f3j9AgMBAAGjgc8wgcwwHQYDVR0OBBYEFCXWzAgVyrbwnFncFFIs
+77VBdlE4MIGcBgNVHSMEgZQwgZGAFCXWzAgVyrbwnFncFFIs77VBdlE4oW6kbDBq
+MQswCQYDVQQGEwJVUzETMBEGA1UECBMKV2FzaGluZ3RvbjEQMA4GA1UEBxMHU2Vh
+dHRsZTEYMBYGA1UEChMPQW1hem9uLmNvbSBJbmMuMRowGAYDVQQDExFlYzIuYW1h
+em9uYXdzLmNvbYIJAKnL4UEDMN/FMAwGA1UdEwQFMAMBAf8wDQYJKoZIhvcNAQEF
+BQADgYEAFYcz1OgEhQBXIwIdsgCOS8vEtiJYF+j9uO6jz7VOmJqO+pRlAbRlvY8T
+C1haGgSI/A1uZUKs/Zfnph0oEI0/hu1IIJ/SKBDtN5lvmZ/IzbOPIJWirlsllQIQ
+7zvWbGd9c9+Rm3p04oTvhup99la7kZqevJK0QRdD/6NpCKsqP/0=
+-----END CERTIFICATE-----`
+
+// awsPKCS7IIDCert is AWS's PKCS7 public certificate used to sign the
+// /pkcs7 instance identity document signature in the commercial ("aws")
+// partition.
+const awsPKCS7IIDCert = `-----BEGIN CERTIFICATE-----
+MIIC7TCCAq0CCQCWukjZ5V4aZzAJBgcqhkjOOAQDMFwxCzAJBgNVBAYTAlVTMRkw
+FwYDVQQIExBXYXNoaW5ndG9uIFN0YXRlMRAwDgYDVQQHEwdTZWF0dGxlMSAwHgYD
+VQQKExdBbWF6b24gV2ViIFNlcnZpY2VzIExMQzAeFw0xMjAxMDUxMjU2MTJaFw0z
+ODAxMDUxMjU2MTJaMFwxCzAJBgNVBAYTAlVTMRkwFwYDVQQIExBXYXNoaW5ndG9u
+IFN0YXRlMRAwDgYDVQQHEwdTZWF0dGxlMSAwHgYDVQQKExdBbWF6b24gV2ViIFNl
+cnZpY2VzIExMQzCCAbcwggEsBgcqhkjOOAQBMIIBHwKBgQCjkvcS2bb1VQ4yt/5e
+ih5OO6kK/n1Lzllr7D8ZwtQP8fOEpp5E2ng+D6Ud1Z1gYipr58Kj3nssSNpI6bX3
+VyIQzK7wLclnd/YozqNNmgIyZecN7EglK9ITHJLP+x8FtUpt3QbyYXJdmVMegN6P
+hviYt5JH/nYl4hh3Pa1HJdskgQIVALVJ3ER11+Ko4tP6nwvHwh6+ERYRAoGBAI1j
+k+tkqMVHuAFcvAGKocTgsjJem6/5qomzJuKDmbJNu9Qxw3rAotXau8Qe+MBcJl/U
+hhy1KHVpCGl9fueQ2s6IL0CaO/buycU1CiYQk40KNHCcHfNiZbdlx1E9rpUp7bnF
+lRa2v1ntMX3caRVDdbtPEWmdxSCYsYFDk4mZrOLBA4GEAAKBgEbmeve5f8LIE/Gf
+MNmP9CM5eovQOGx5ho8WqD+aTebs+k2tn92BBPqeZqpWRa5P/+jrdKml1qx4llHW
+MXrs3IgIb6+hUIB+S8dz8/mmO0bpr76RoZVCXYab2CZedFut7qc3WUH9+EUAH5mw
+vSeDCOUMYQR7R9LINYwouHIziqQYMAkGByqGSM44BAMDLwAwLAIUWXBlk40xTwSw
+7HX32MxXYruse9ACFBNGmdX2ZBrVNGrN9N2f6ROk0k9K
+-----END CERTIFICATE-----`
+
+// awsUSGovIIDCert is AWS's public certificate for the GovCloud
+// ("aws-us-gov") partition, used for both the RSA and PKCS7 signature
+// formats.
+const awsUSGovIIDCert = `-----BEGIN CERTIFICATE-----
+MIIDqDCCApCgAwIBAgIIGMa+stNYk4MwDQYJKoZIhvcNAQELBQAwcjELMAkGA1UE
+BhMCVVMxEzARBgNVBAgTCldhc2hpbmd0b24xEDAOBgNVBAcTB1NlYXR0bGUxIDAe
+BgNVBAoTF0FtYXpvbiBXZWIgU2VydmljZXMgTExDMRowGAYDVQQDExFlYzIuYW1h
+em9uYXdzLmNvbTAeFw0xNDA2MDUxNDI4MDJaFw0zNDA2MDUxNDI4MDJaMHIxCzAJ
+BgNVBAYTAlVTMRMwEQYDVQQIEwpXYXNoaW5ndG9uMRAwDgYDVQQHEwdTZWF0dGxl
+MSAwHgYDVQQKExdBbWF6b24gV2ViIFNlcnZpY2VzIExMQzEaMBgGA1UEAxMRZWMy
+LmFtYXpvbmF3cy5jb20wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEKAoIBAQDW
+nWtpjtmFUnpDnq6t6RoiVAiLkn055XC0/o31kcC72mKecjAt0B6qn3hXIp2KADnM
+oZztq/wYk+uU3vsryLp/OP1O0qdxi6Za7CpBZusBvsXUIPFrnySqBzx9GMivGCYL
+Uyx8bA0Iq3BK37iH0rJeMp26Yhs68tMiGJizg/rda1K5WhN8DC9hCCGr/zza0LvA
+Xj/NsKzIKrgdwK7/XY999oF9r3DOSqjhH7TdkYZr5XcT1T8vk0Mfp3HI8qU4YD21
+VHP3QRyoWFq91yBK7NfxZ9gjUyQcC2weqRK9XaB7qt0DO6/8s/CuCiWIlBVCQXNi
+DfMlg/oNUu7XoCXs7OcvAgMBAAGjQjBAMA4GA1UdDwEB/wQEAwIChDAPBgNVHRMB
+Af8EBTADAQH/MB0GA1UdDgQWBBTRbY0Z4uh3EJ4lAE0eV2jj7FfOmTANBgkqhkiG
+9w0BAQsFAAOCAQEAnOKaPKlPPFjcOuYbNSc9I5p81+LlJozLYkaTvcsEcbpNJUMT
+7S3C36lyT9zsy0khYgut+h25QuLzr1fbK1Kh/+ZKlOAI8FndjyD9xDF7stvUl5eg
+1B19MtZOOI7zI4ZiLs2EnT7vyRACnbDoQj74TzYdk5Ezh4YUz0vSx54rcUp756Iv
+cNCMqugXphqx92zqiC2sKATp5U3ipxtdK/2Guz7DYp3dcSJeNvCIoXnmp9Soyl67
+DLmKaZ/lbAIOZ1wSA7MMHkj1PnTngmsrcu1jR5eK2PsdtP0T7wP7emRgw3lm69aN
+wlMdeXrqKPjweILOXdl/d0uSCyAgpI1qu41ivQ==
+-----END CERTIFICATE-----`
+
+// awsCNIIDCert is AWS's public certificate for the China ("aws-cn")
+// partition, used for both the RSA and PKCS7 signature formats.
+const awsCNIIDCert = `-----BEGIN CERTIFICATE-----
+MIIDrjCCApagAwIBAgIIGMa+st+iP48wDQYJKoZIhvcNAQELBQAwdTELMAkGA1UE
+BhMCVVMxEzARBgNVBAgTCldhc2hpbmd0b24xEDAOBgNVBAcTB1NlYXR0bGUxIDAe
+BgNVBAoTF0FtYXpvbiBXZWIgU2VydmljZXMgTExDMR0wGwYDVQQDExRlYzIuYW1h
+em9uYXdzLmNvbS5jbjAeFw0xNDA2MDUxNDI4MDJaFw0zNDA2MDUxNDI4MDJaMHUx
+CzAJBgNVBAYTAlVTMRMwEQYDVQQIEwpXYXNoaW5ndG9uMRAwDgYDVQQHEwdTZWF0
+dGxlMSAwHgYDVQQKExdBbWF6b24gV2ViIFNlcnZpY2VzIExMQzEdMBsGA1UEAxMU
+ZWMyLmFtYXpvbmF3cy5jb20uY24wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEK
+AoIBAQDRkkdxIzMTrqL9xqH67RtbcroxfD2zS0wLSZ4t3h/w0DfA+vL3I7Krk9D9
+8tOlky3TJ8r/uFbnUivRqSGOzWm2FwcfSHKdyOvexq3lJ0qF9vz28Fcybucib7rk
+Wws444sKTXFYdEvXlpX7qOO0YQs+BuDnLDOGtQDRiHWqTavCZvoNDV9Jtqa0XtBJ
++u1qOh/zxc6gMZiXNrLQ/zgb86gl3hOXdzD7CHO3PLaKey/KqUm5jYZofdJEd4On
+hlGdQgIR60AS/7yb3VBx6s/HSrxtkNdH5tP5PSkP6q547DkQhPNRNMxI+2FAs4Cc
+qTwL76Hkknb8VO3EWZvtxG+ddDVPAgMBAAGjQjBAMA4GA1UdDwEB/wQEAwIChDAP
+BgNVHRMBAf8EBTADAQH/MB0GA1UdDgQWBBTpB4Fakl8ANQ/9QsgT2173yk0JZDAN
+BgkqhkiG9w0BAQsFAAOCAQEAlHDOzgeXqvj2aMv4wL6tJusM2JTRBB/DbqcA2KTw
+6U1ROHXOmkuy/1UQx4bddXj6UAsc9gqNjmX4/bGUbpuTmFSruO+I4cuY4iyI9y+l
+xDMuUu9PowHdLrbmUJ2gDJoxNCjvOi30BJ5FEeCzluT313Z3BM63hAE9P9CFG6va
+HiKFmhYZirBIisYY0/JTneY38vf/R81wFHYYHzZ3AVdts9ILkx+QD1bDEc9lnL0t
+Rf9hiRok+IOWv/acmgQTzLgsTYjB4Qzdel+cLIVuTJWj+Hic8XwrdxaP/MFYwx4e
+tgxEdAQQNNHUXnHOPFKlFZg8UyI2RlJQzvgTuPRirsYRsg==
+-----END CERTIFICATE-----`
+
+// partitionPEMs holds the raw PEM certificates published for a
+// partition.
+type partitionPEMs struct {
+	rsaCert   string
+	pkcs7Cert string
+}
+
+// pemsByPartition maps every partition this package knows about to its
+// published certificates. Every region belongs to exactly one of these
+// partitions; see PartitionForRegion.
+var pemsByPartition = map[Partition]partitionPEMs{
+	PartitionAWS:      {rsaCert: awsRSAIIDCert, pkcs7Cert: awsPKCS7IIDCert},
+	PartitionAWSCN:    {rsaCert: awsCNIIDCert, pkcs7Cert: awsCNIIDCert},
+	PartitionAWSUSGov: {rsaCert: awsUSGovIIDCert, pkcs7Cert: awsUSGovIIDCert},
+}
+
+type parsedCertPair struct {
+	rsaCert   *x509.Certificate
+	pkcs7Cert *x509.Certificate
+}
+
+// parsedCertsByPartition caches the parsed form of pemsByPartition,
+// populated once in init.
+var parsedCertsByPartition = map[Partition]parsedCertPair{}
+
+func init() {
+	for partition, pems := range pemsByPartition {
+		rsaCert, err := parseCertPEM(pems.rsaCert)
+		if err != nil {
+			panic(fmt.Sprintf("iidverify: parsing RSA certificate for partition %q: %v", partition, err))
+		}
+		pkcs7Cert, err := parseCertPEM(pems.pkcs7Cert)
+		if err != nil {
+			panic(fmt.Sprintf("iidverify: parsing PKCS7 certificate for partition %q: %v", partition, err))
+		}
+		parsedCertsByPartition[partition] = parsedCertPair{rsaCert: rsaCert, pkcs7Cert: pkcs7Cert}
+	}
+}
+
+// PartitionForRegion returns the AWS partition that region belongs to. It
+// defaults to PartitionAWS for unrecognized regions, since that is the
+// partition the vast majority of regions belong to.
+//
+// This includes AWS's opt-in regions (ap-east-1, me-south-1, af-south-1,
+// eu-south-1, ap-southeast-3, and similar), which publish their own IID
+// signing certificates that this package does not yet bundle separately;
+// see the Partition doc comment.
+func PartitionForRegion(region string) Partition {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionAWSCN
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionAWSUSGov
+	default:
+		return PartitionAWS
+	}
+}
+
+// certsForRegion resolves the certificates published for the partition
+// region belongs to (see PartitionForRegion).
+func certsForRegion(region string) (parsedCertPair, error) {
+	certs, ok := parsedCertsByPartition[PartitionForRegion(region)]
+	if !ok {
+		return parsedCertPair{}, fmt.Errorf("iidverify: no certificate known for region %q", region)
+	}
+	return certs, nil
+}
+
+// CertificateForRegion returns the RSA signing certificate for the
+// partition region belongs to.
+func CertificateForRegion(region string) (*x509.Certificate, error) {
+	certs, err := certsForRegion(region)
+	if err != nil {
+		return nil, err
+	}
+	return certs.rsaCert, nil
+}
+
+// parseCertPEM decodes a single PEM-encoded certificate block.
+func parseCertPEM(pemCert string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(pemCert))
+	if block == nil {
+		return nil, fmt.Errorf("iidverify: no PEM block found in certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
@@ -0,0 +1,73 @@
+// Package iidverify verifies AWS EC2 Instance Identity Documents (IIDs).
+//
+// An instance can fetch a document describing itself from the instance
+// metadata service along with a signature over that document, and a peer
+// can use that signature to confirm the document really was produced by
+// AWS for that instance. This package implements that verification for
+// both of the signature formats AWS offers: the raw RSA signature and the
+// PKCS7 signature.
+//
+// See http://docs.aws.amazon.com/AWSEC2/latest/UserGuide/instance-identity-documents.html
+package iidverify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrSignatureMismatch is returned when a signature does not verify
+// against the identity document it claims to cover.
+var ErrSignatureMismatch = errors.New("iidverify: signature does not match identity document")
+
+// ErrMalformedDocument is returned when the identity document or its
+// signature cannot be parsed.
+var ErrMalformedDocument = errors.New("iidverify: malformed identity document or signature")
+
+// ErrExpiredDocument is returned when a document's pendingTime is older
+// than the Verifier's configured MaxAge.
+var ErrExpiredDocument = errors.New("iidverify: identity document has expired")
+
+// ErrReplayedDocument is returned when the Verifier's NonceStore reports
+// that it has already seen this document.
+var ErrReplayedDocument = errors.New("iidverify: identity document has already been used")
+
+// ErrAccountNotAllowed is returned when a document's account is not in
+// the Verifier's ExpectedAccountIDs allow-list.
+var ErrAccountNotAllowed = errors.New("iidverify: account is not in the allowed list")
+
+// ErrRegionNotAllowed is returned when a document's region is not in the
+// Verifier's ExpectedRegions allow-list.
+var ErrRegionNotAllowed = errors.New("iidverify: region is not in the allowed list")
+
+// IdentityDocument is the parsed contents of an AWS EC2 instance identity
+// document, as returned by the instance metadata service at
+// /latest/dynamic/instance-identity/document.
+type IdentityDocument struct {
+	AccountID               string   `json:"accountId"`
+	Architecture            string   `json:"architecture"`
+	AvailabilityZone        string   `json:"availabilityZone"`
+	BillingProducts         []string `json:"billingProducts"`
+	DevpayProductCodes      []string `json:"devpayProductCodes"`
+	MarketplaceProductCodes []string `json:"marketplaceProductCodes"`
+	ImageID                 string   `json:"imageId"`
+	InstanceID              string   `json:"instanceId"`
+	InstanceType            string   `json:"instanceType"`
+	KernelID                string   `json:"kernelId"`
+	PendingTime             string   `json:"pendingTime"`
+	PrivateIP               string   `json:"privateIp"`
+	RamdiskID               string   `json:"ramdiskId"`
+	Region                  string   `json:"region"`
+	Version                 string   `json:"version"`
+}
+
+// parseDocument unmarshals the raw JSON identity document, wrapping any
+// failure in ErrMalformedDocument so callers can distinguish it from a
+// signature mismatch.
+func parseDocument(doc []byte) (*IdentityDocument, error) {
+	var id IdentityDocument
+	if err := json.Unmarshal(doc, &id); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedDocument, err)
+	}
+	return &id, nil
+}
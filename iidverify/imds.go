@@ -0,0 +1,175 @@
+package iidverify
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultIMDSBaseURL = "http://169.254.169.254"
+	defaultTokenTTL    = 21600 * time.Second
+	defaultHTTPTimeout = 5 * time.Second
+
+	// tokenRefreshBuffer is how far ahead of a cached token's expiry
+	// IMDSClient fetches a new one, to avoid racing a request against
+	// expiry.
+	tokenRefreshBuffer = 30 * time.Second
+
+	imdsTokenPath      = "/latest/api/token"
+	imdsTokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+	imdsTokenHeader    = "X-aws-ec2-metadata-token"
+
+	imdsDocumentPath  = "/latest/dynamic/instance-identity/document"
+	imdsSignaturePath = "/latest/dynamic/instance-identity/signature"
+	imdsPKCS7Path     = "/latest/dynamic/instance-identity/pkcs7"
+)
+
+// IMDSClient fetches instance identity material from the EC2 instance
+// metadata service. It uses IMDSv2 (session-oriented, token-based
+// requests) by default; instances configured to require IMDSv2 reject
+// the plain GETs IMDSv1 uses.
+type IMDSClient struct {
+	baseURL     string
+	httpClient  *http.Client
+	tokenTTL    time.Duration
+	allowIMDSv1 bool
+
+	mu             sync.Mutex
+	token          string
+	tokenExpiresAt time.Time
+}
+
+// IMDSOption configures an IMDSClient.
+type IMDSOption func(*IMDSClient)
+
+// WithIMDSBaseURL overrides the instance metadata service's base URL.
+// Mainly useful for testing against a fake IMDS.
+func WithIMDSBaseURL(baseURL string) IMDSOption {
+	return func(c *IMDSClient) { c.baseURL = baseURL }
+}
+
+// WithHTTPTimeout overrides the per-request timeout. IMDSClient defaults
+// to a short timeout since off-EC2 requests to the metadata service's
+// link-local address otherwise hang until the caller gives up.
+func WithHTTPTimeout(timeout time.Duration) IMDSOption {
+	return func(c *IMDSClient) { c.httpClient.Timeout = timeout }
+}
+
+// WithIMDSv1Fallback allows IMDSClient to fall back to an unauthenticated
+// IMDSv1 request when it cannot obtain an IMDSv2 token. Off by default,
+// since silently falling back is how IMDSv2-only hardening gets bypassed
+// without anyone noticing.
+func WithIMDSv1Fallback() IMDSOption {
+	return func(c *IMDSClient) { c.allowIMDSv1 = true }
+}
+
+// NewIMDSClient builds an IMDSClient targeting the instance metadata
+// service at its well-known link-local address.
+func NewIMDSClient(opts ...IMDSOption) *IMDSClient {
+	c := &IMDSClient{
+		baseURL:    defaultIMDSBaseURL,
+		httpClient: &http.Client{Timeout: defaultHTTPTimeout},
+		tokenTTL:   defaultTokenTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// FetchIdentityDocument fetches the raw instance identity document.
+func (c *IMDSClient) FetchIdentityDocument(ctx context.Context) ([]byte, error) {
+	return c.fetch(ctx, imdsDocumentPath)
+}
+
+// FetchRSASignature fetches the raw (base64) RSA signature over the
+// instance identity document.
+func (c *IMDSClient) FetchRSASignature(ctx context.Context) ([]byte, error) {
+	return c.fetch(ctx, imdsSignaturePath)
+}
+
+// FetchPKCS7Signature fetches the raw (base64) PKCS7 signature over the
+// instance identity document.
+func (c *IMDSClient) FetchPKCS7Signature(ctx context.Context) ([]byte, error) {
+	return c.fetch(ctx, imdsPKCS7Path)
+}
+
+// fetch performs a token-authenticated GET against path, falling back to
+// an unauthenticated request only when WithIMDSv1Fallback was set.
+func (c *IMDSClient) fetch(ctx context.Context, path string) ([]byte, error) {
+	token, err := c.ensureToken(ctx)
+	if err != nil {
+		if !c.allowIMDSv1 {
+			return nil, fmt.Errorf("iidverify: fetching IMDSv2 token: %w", err)
+		}
+		token = ""
+	}
+	return c.get(ctx, path, token)
+}
+
+// ensureToken returns a cached token if it has enough life left, or
+// fetches a new one.
+func (c *IMDSClient) ensureToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiresAt.Add(-tokenRefreshBuffer)) {
+		return c.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+imdsTokenPath, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(imdsTokenTTLHeader, strconv.Itoa(int(c.tokenTTL.Seconds())))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("iidverify: IMDS token request returned %s: %s", resp.Status, body)
+	}
+
+	c.token = string(body)
+	c.tokenExpiresAt = time.Now().Add(c.tokenTTL)
+	return c.token, nil
+}
+
+// get performs a single GET against path, attaching token as the IMDSv2
+// token header when non-empty.
+func (c *IMDSClient) get(ctx context.Context, path, token string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set(imdsTokenHeader, token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("iidverify: IMDS request for %s returned %s: %s", path, resp.Status, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,79 @@
+package iidverify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestIMDSClientFetchIdentityDocument(t *testing.T) {
+	var tokenRequests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == imdsTokenPath:
+			if r.Header.Get(imdsTokenTTLHeader) == "" {
+				t.Errorf("token request missing %s header", imdsTokenTTLHeader)
+			}
+			atomic.AddInt32(&tokenRequests, 1)
+			w.Write([]byte("test-token"))
+		case r.Method == http.MethodGet && r.URL.Path == imdsDocumentPath:
+			if got := r.Header.Get(imdsTokenHeader); got != "test-token" {
+				t.Errorf("document request token header = %q, want %q", got, "test-token")
+			}
+			w.Write([]byte(`{"region":"us-east-1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewIMDSClient(WithIMDSBaseURL(server.URL))
+
+	for i := 0; i < 3; i++ {
+		doc, err := client.FetchIdentityDocument(context.Background())
+		if err != nil {
+			t.Fatalf("FetchIdentityDocument() error = %v", err)
+		}
+		if string(doc) != `{"region":"us-east-1"}` {
+			t.Errorf("FetchIdentityDocument() = %q", doc)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("token was requested %d times, want 1 (should be cached)", tokenRequests)
+	}
+}
+
+func TestIMDSClientFallsBackToIMDSv1OnlyWhenEnabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == imdsTokenPath:
+			http.Error(w, "IMDSv2 disabled", http.StatusForbidden)
+		case r.Method == http.MethodGet && r.URL.Path == imdsDocumentPath:
+			if r.Header.Get(imdsTokenHeader) != "" {
+				t.Errorf("unauthenticated request unexpectedly carried a token header")
+			}
+			w.Write([]byte(`{"region":"us-east-1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	withoutFallback := NewIMDSClient(WithIMDSBaseURL(server.URL))
+	if _, err := withoutFallback.FetchIdentityDocument(context.Background()); err == nil {
+		t.Fatal("FetchIdentityDocument() succeeded without a token and without WithIMDSv1Fallback")
+	}
+
+	withFallback := NewIMDSClient(WithIMDSBaseURL(server.URL), WithIMDSv1Fallback())
+	doc, err := withFallback.FetchIdentityDocument(context.Background())
+	if err != nil {
+		t.Fatalf("FetchIdentityDocument() error = %v", err)
+	}
+	if string(doc) != `{"region":"us-east-1"}` {
+		t.Errorf("FetchIdentityDocument() = %q", doc)
+	}
+}
@@ -0,0 +1,97 @@
+package iidverify
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// VerifierOptions holds the configuration applied by a Verifier's
+// Option values. Callers don't normally build one of these directly;
+// use the With* functions with NewVerifier instead.
+type VerifierOptions struct {
+	// Region, if set, pins the Verifier to the certificates published
+	// for the partition this region belongs to (see PartitionForRegion),
+	// instead of auto-detecting the partition from each document's
+	// region field.
+	Region string
+
+	// Partition, if set, pins the Verifier to this partition's
+	// certificates. Takes precedence over Region.
+	Partition Partition
+
+	// CustomRSACert and CustomPKCS7Cert, if set, override the
+	// certificates a Verifier would otherwise select.
+	CustomRSACert   *x509.Certificate
+	CustomPKCS7Cert *x509.Certificate
+
+	// MaxAge, if nonzero, rejects documents whose pendingTime is older
+	// than this, to limit how long a captured document remains usable.
+	MaxAge time.Duration
+
+	// ExpectedAccountIDs, if non-empty, rejects documents whose
+	// accountId isn't in this list.
+	ExpectedAccountIDs []string
+
+	// ExpectedRegions, if non-empty, rejects documents whose region
+	// isn't in this list.
+	ExpectedRegions []string
+
+	// NonceStore, if set, rejects documents it reports as already seen,
+	// letting a caller treat a signature-valid document as single-use.
+	NonceStore NonceStore
+}
+
+// Option configures a Verifier. See WithRegion, WithPartition, and
+// WithCustomCertificate.
+type Option func(*VerifierOptions)
+
+// WithRegion pins a Verifier to the certificates published for the
+// partition region belongs to, rather than auto-detecting the partition
+// from each document's own region field.
+func WithRegion(region string) Option {
+	return func(o *VerifierOptions) { o.Region = region }
+}
+
+// WithPartition pins a Verifier to partition's certificates directly.
+func WithPartition(partition Partition) Option {
+	return func(o *VerifierOptions) { o.Partition = partition }
+}
+
+// WithCustomCertificate overrides the RSA and/or PKCS7 signing
+// certificates a Verifier uses. This lets operators get ahead of an AWS
+// certificate rotation without waiting for a release; pass nil for a
+// certificate to leave it selected by Region/Partition as normal.
+func WithCustomCertificate(rsaCert, pkcs7Cert *x509.Certificate) Option {
+	return func(o *VerifierOptions) {
+		o.CustomRSACert = rsaCert
+		o.CustomPKCS7Cert = pkcs7Cert
+	}
+}
+
+// WithMaxAge rejects documents whose pendingTime is older than maxAge.
+// A valid signature only proves AWS produced the document at some point;
+// without this, a captured document remains usable forever.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(o *VerifierOptions) { o.MaxAge = maxAge }
+}
+
+// WithExpectedAccountIDs rejects documents whose accountId isn't one of
+// accountIDs.
+func WithExpectedAccountIDs(accountIDs ...string) Option {
+	return func(o *VerifierOptions) { o.ExpectedAccountIDs = accountIDs }
+}
+
+// WithExpectedRegions rejects documents whose region isn't one of
+// regions.
+func WithExpectedRegions(regions ...string) Option {
+	return func(o *VerifierOptions) { o.ExpectedRegions = regions }
+}
+
+// WithNonceStore rejects documents that store reports as already seen,
+// turning a signature-valid document into a single-use credential. This
+// is what makes the library safe to use as an authentication backend
+// (rather than just a one-off verification check): without it, anyone
+// who captures a signed document in transit can replay it indefinitely.
+func WithNonceStore(store NonceStore) Option {
+	return func(o *VerifierOptions) { o.NonceStore = store }
+}
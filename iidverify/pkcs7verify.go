@@ -0,0 +1,163 @@
+package iidverify
+
+import (
+	"crypto"
+	"crypto/dsa"
+	"crypto/hmac"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+// attribute mirrors the unexported pkcs7.attribute type so we can
+// re-encode a signer's authenticated attributes ourselves; see
+// marshalAttributesForDigest.
+type attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+var oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+// digestAlgorithms maps the digest algorithm OIDs AWS's signers use to
+// their crypto.Hash.
+var digestAlgorithms = map[string]crypto.Hash{
+	"1.3.14.3.2.26":          crypto.SHA1,
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+}
+
+// verifyPKCS7SignedData checks every signer on p7 against cert. This
+// exists because the fullsailor/pkcs7 dependency's own Verify method
+// goes through crypto/x509's CheckSignature, and Go's x509 package has
+// dropped DSA signature verification -- which is exactly the algorithm
+// AWS's bundled PKCS7 certificate uses.
+func verifyPKCS7SignedData(p7 *pkcs7.PKCS7, cert *x509.Certificate) error {
+	if len(p7.Signers) == 0 {
+		return fmt.Errorf("%w: PKCS7 message has no signers", ErrMalformedDocument)
+	}
+
+	for _, signer := range p7.Signers {
+		attrs := make([]attribute, len(signer.AuthenticatedAttributes))
+		for i, a := range signer.AuthenticatedAttributes {
+			attrs[i] = attribute{Type: a.Type, Value: a.Value}
+		}
+
+		if err := verifySignerInfo(p7.Content, signer.DigestAlgorithm, attrs, signer.DigestEncryptionAlgorithm, signer.EncryptedDigest, cert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifySignerInfo(content []byte, digestAlg pkix.AlgorithmIdentifier, authAttrs []attribute, digestEncAlg pkix.AlgorithmIdentifier, encryptedDigest []byte, cert *x509.Certificate) error {
+	hash, ok := digestAlgorithms[digestAlg.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("%w: unsupported digest algorithm %s", ErrMalformedDocument, digestAlg.Algorithm)
+	}
+
+	signedBytes := content
+	if len(authAttrs) > 0 {
+		var gotDigest []byte
+		if err := attributeValue(authAttrs, oidAttributeMessageDigest, &gotDigest); err != nil {
+			return fmt.Errorf("%w: reading signed message digest attribute: %v", ErrMalformedDocument, err)
+		}
+		if !hmac.Equal(gotDigest, hashBytes(hash, content)) {
+			return fmt.Errorf("%w: signed message digest does not match document", ErrSignatureMismatch)
+		}
+
+		var err error
+		signedBytes, err = marshalAttributesForDigest(authAttrs)
+		if err != nil {
+			return fmt.Errorf("%w: re-encoding signed attributes: %v", ErrMalformedDocument, err)
+		}
+	}
+
+	if dsaKey, ok := cert.PublicKey.(*dsa.PublicKey); ok {
+		return verifyDSASignature(dsaKey, hash, signedBytes, encryptedDigest)
+	}
+
+	algo := signatureAlgorithmFor(cert.PublicKeyAlgorithm, hash)
+	if err := cert.CheckSignature(algo, signedBytes, encryptedDigest); err != nil {
+		return fmt.Errorf("%w: %v", ErrSignatureMismatch, err)
+	}
+	return nil
+}
+
+// verifyDSASignature verifies sig, a DER SEQUENCE{r, s INTEGER}, over
+// signedBytes against pub.
+func verifyDSASignature(pub *dsa.PublicKey, hash crypto.Hash, signedBytes, sig []byte) error {
+	var parsedSig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(sig, &parsedSig); err != nil {
+		return fmt.Errorf("%w: decoding DSA signature: %v", ErrMalformedDocument, err)
+	}
+
+	if !dsa.Verify(pub, hashBytes(hash, signedBytes), parsedSig.R, parsedSig.S) {
+		return fmt.Errorf("%w: DSA signature does not verify", ErrSignatureMismatch)
+	}
+	return nil
+}
+
+func signatureAlgorithmFor(pubAlgo x509.PublicKeyAlgorithm, hash crypto.Hash) x509.SignatureAlgorithm {
+	switch pubAlgo {
+	case x509.RSA:
+		if hash == crypto.SHA256 {
+			return x509.SHA256WithRSA
+		}
+		if hash == crypto.SHA1 {
+			return x509.SHA1WithRSA
+		}
+	case x509.ECDSA:
+		if hash == crypto.SHA256 {
+			return x509.ECDSAWithSHA256
+		}
+		if hash == crypto.SHA1 {
+			return x509.ECDSAWithSHA1
+		}
+	}
+	return x509.UnknownSignatureAlgorithm
+}
+
+func hashBytes(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// attributeValue decodes the value of the first attribute in attrs whose
+// type matches oid into out.
+func attributeValue(attrs []attribute, oid asn1.ObjectIdentifier, out interface{}) error {
+	for _, attr := range attrs {
+		if attr.Type.Equal(oid) {
+			_, err := asn1.Unmarshal(attr.Value.Bytes, out)
+			return err
+		}
+	}
+	return fmt.Errorf("attribute %s not present", oid)
+}
+
+// marshalAttributesForDigest re-encodes authenticated attributes as a
+// SET OF Attribute (DER tag 0x31), as required for the message digest
+// computation, in place of the IMPLICIT [0] tag (0xA0) they carry on the
+// wire inside SignerInfo.
+func marshalAttributesForDigest(attrs []attribute) ([]byte, error) {
+	encoded, err := asn1.Marshal(struct {
+		A []attribute `asn1:"set"`
+	}{A: attrs})
+	if err != nil {
+		return nil, err
+	}
+
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(encoded, &raw); err != nil {
+		return nil, err
+	}
+	return raw.Bytes, nil
+}
@@ -0,0 +1,95 @@
+package iidverify
+
+import (
+	"crypto/dsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+// These values were captured from a synthetic DSA-signed PKCS7 message
+// built with the same ASN.1 layout AWS uses for the /pkcs7 endpoint
+// (authenticated attributes present, SHA-256 digest, DSA signature).
+const (
+	dsaTestPKCS7B64 = "MIIBUAYJKoZIhvcNAQcCoIIBQTCCAT0CAQExDTALBglghkgBZQMEAgEwSAYJKoZIhvcNAQcBoDuEOXsicmVnaW9uIjoidXMtZWFzdC0xIiwiaW5zdGFuY2VJZCI6ImktMDEyMzQ1Njc4OWFiY2RlZjAifTGB3jCB2wIBATAnMCIxIDAeBgNVBAMTF0FtYXpvbiBXZWIgU2VydmljZXMgTExDAgEBMAsGCWCGSAFlAwQCAaBLMBgGCSqGSIb3DQEJAzELBgkqhkiG9w0BBwEwLwYJKoZIhvcNAQkEMSIEIGl9JLg4MK4K3R7nwK+WH8XDnoGM9ZxG6gpnN8nFHxn3MAkGByqGSM44BAEESDBGAiEAuVfSrTQHEZ0ireWlN38mkAc9UqO/Du7y4DDAF3hU+UwCIQCc1FqucMasH5OcOZ6wUgUZmVmbYMHoQ3UBuL12Ct6KKg=="
+
+	dsaTestP = "rxTbnvqBBGnu72G0L58bcaPvwAwQaW0tYOEP6Sa5qolxG/I7PQBYhEBqQonmfRm+QRXIFcDjIMXr9vivz71B8jD+grCMqQKozogNqzpE74B5WlxW5rAUPvEvyW+UzTRwyNuy3dEpXbmJ6xfNywuFH9oXK6UYTCQiPO6xIlV7++hwoDdqOOELy1uyhoPfWpN2Ozzw8m4twbmOWcY3L9VCflVhHpCbczvC7kJmkqdlgUCO6PGMfVc5dFP17LbzAEvV8JNnVKrokAAjaUp2C2XMCqpD7axMryrgfjZ1SUc80Omb2SnBOAlKC8y5IhVC8FrLrCh/x1t6ndG96jLHgN5WDw=="
+	dsaTestQ = "4q7Lo0zrXOxnbmkXGkP8iIo1PodqvUyOlEHfpoyBlbk="
+	dsaTestG = "Y2GSlO1FWj+VhzL6mlusvLCdrGz9gYxrGx981+Y46VcISIr6cZ6WPJAofI1yT363+Rr537GKCweSqEB1NZ6b7K7HciRUnGBAFynpUDaIifznInHOtuUyt/Xz09SMRTTny8uAd/viSMYLjSP8lw0XAOUzzpQBxbhulATysfgfJhXgWyrprSmTPurur8z+rcsKsmnvx7n6tS9mYnlJowAF3q1ZVtmgf5n/Ylo9Dh1LilU0JTs8X9kZ1358IpUhy7WkVBXf0Hcu5PNwm0IMHeOEl3VdL0M02Sk2tlEoU9r9vKZ8g5lghZNFu9X1WjZHSdz2DiYqU3E1jsYzMvzcHNchKQ=="
+	dsaTestY = "Ut+LAq1YW7deKz/2xNH4NwkTSkEMV6+IzP8CC3+58SmBasaf7fA5JgORbBRnFET2h4KpFluwbI2r2/l/S6UofHitDHv+BCFEmHSpAYxvLSRseEz97gzvXGZF/bxxBRLImEoGHLsNW3She8aiBYFYq6rkaHKavpv+/o/dSdBhXywp0PUdHHf0jmYyqzc2iTz9SBe+iH11L5gnfZhFhxI3RN4cGpk4e2loiIICYh5k+hItF0WM21u0Ox7UNTG6hC84iTpH02HOvP98Fqk+2cExkmZXwQuidblMmOaM5SUIK179drjCXSq1/kK767immsJuWLOz1Eo/poynkTcTA18mvA=="
+)
+
+func mustBigInt(t *testing.T, b64 string) *big.Int {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		t.Fatalf("decoding base64: %v", err)
+	}
+	return new(big.Int).SetBytes(raw)
+}
+
+func dsaTestCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	pub := dsa.PublicKey{
+		Parameters: dsa.Parameters{
+			P: mustBigInt(t, dsaTestP),
+			Q: mustBigInt(t, dsaTestQ),
+			G: mustBigInt(t, dsaTestG),
+		},
+		Y: mustBigInt(t, dsaTestY),
+	}
+	return &x509.Certificate{PublicKeyAlgorithm: x509.DSA, PublicKey: &pub}
+}
+
+func TestVerifyPKCS7SignedDataDSA(t *testing.T) {
+	der, err := base64.StdEncoding.DecodeString(dsaTestPKCS7B64)
+	if err != nil {
+		t.Fatalf("decoding test PKCS7 blob: %v", err)
+	}
+
+	p7, err := pkcs7.Parse(der)
+	if err != nil {
+		t.Fatalf("parsing test PKCS7 blob: %v", err)
+	}
+
+	cert := dsaTestCert(t)
+
+	tests := []struct {
+		name    string
+		cert    *x509.Certificate
+		wantErr error
+	}{
+		{name: "correct certificate", cert: cert},
+		{
+			name: "wrong certificate",
+			cert: func() *x509.Certificate {
+				c := *cert
+				y := new(big.Int).Add(cert.PublicKey.(*dsa.PublicKey).Y, big.NewInt(2))
+				pub := *cert.PublicKey.(*dsa.PublicKey)
+				pub.Y = y
+				c.PublicKey = &pub
+				return &c
+			}(),
+			wantErr: ErrSignatureMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyPKCS7SignedData(p7, tt.cert)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("verifyPKCS7SignedData() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verifyPKCS7SignedData() = %v, want error wrapping %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package iidverify
+
+import (
+	"fmt"
+	"time"
+)
+
+// NonceStore lets a Verifier detect replayed identity documents. Seen
+// reports whether the (instanceID, pendingTime) pair has already been
+// recorded, recording it as seen if this is the first time. A document
+// is identified by this pair because pendingTime advances every time
+// AWS reissues an instance's document, so the combination is unique per
+// document even though instanceID alone is not.
+//
+// Implementations must be safe for concurrent use.
+type NonceStore interface {
+	Seen(instanceID, pendingTime string) bool
+}
+
+// checkPolicy applies the Verifier's freshness, allow-list, and replay
+// checks to a document whose signature has already been verified.
+func (v *Verifier) checkPolicy(id *IdentityDocument) error {
+	if v.maxAge > 0 {
+		pendingTime, err := time.Parse(time.RFC3339, id.PendingTime)
+		if err != nil {
+			return fmt.Errorf("%w: parsing pendingTime %q: %v", ErrMalformedDocument, id.PendingTime, err)
+		}
+		if age := time.Since(pendingTime); age > v.maxAge {
+			return fmt.Errorf("%w: pendingTime %s is %s old, older than the %s limit", ErrExpiredDocument, id.PendingTime, age, v.maxAge)
+		}
+	}
+
+	if len(v.expectedAccountIDs) > 0 && !contains(v.expectedAccountIDs, id.AccountID) {
+		return fmt.Errorf("%w: %q", ErrAccountNotAllowed, id.AccountID)
+	}
+
+	if len(v.expectedRegions) > 0 && !contains(v.expectedRegions, id.Region) {
+		return fmt.Errorf("%w: %q", ErrRegionNotAllowed, id.Region)
+	}
+
+	if v.nonceStore != nil && v.nonceStore.Seen(id.InstanceID, id.PendingTime) {
+		return fmt.Errorf("%w: instance %q, pendingTime %q", ErrReplayedDocument, id.InstanceID, id.PendingTime)
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package iidverify
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeNonceStore struct {
+	seen map[string]bool
+}
+
+func (f *fakeNonceStore) Seen(instanceID, pendingTime string) bool {
+	key := instanceID + "/" + pendingTime
+	if f.seen[key] {
+		return true
+	}
+	if f.seen == nil {
+		f.seen = map[string]bool{}
+	}
+	f.seen[key] = true
+	return false
+}
+
+func TestVerifierCheckPolicy(t *testing.T) {
+	freshPendingTime := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	stalePendingTime := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name    string
+		v       *Verifier
+		id      *IdentityDocument
+		wantErr error
+	}{
+		{
+			name:    "no policy configured",
+			v:       &Verifier{},
+			id:      &IdentityDocument{PendingTime: freshPendingTime},
+			wantErr: nil,
+		},
+		{
+			name:    "within max age",
+			v:       &Verifier{maxAge: 10 * time.Minute},
+			id:      &IdentityDocument{PendingTime: freshPendingTime},
+			wantErr: nil,
+		},
+		{
+			name:    "older than max age",
+			v:       &Verifier{maxAge: 10 * time.Minute},
+			id:      &IdentityDocument{PendingTime: stalePendingTime},
+			wantErr: ErrExpiredDocument,
+		},
+		{
+			name:    "account allowed",
+			v:       &Verifier{expectedAccountIDs: []string{"111111111111", "222222222222"}},
+			id:      &IdentityDocument{PendingTime: freshPendingTime, AccountID: "222222222222"},
+			wantErr: nil,
+		},
+		{
+			name:    "account not allowed",
+			v:       &Verifier{expectedAccountIDs: []string{"111111111111"}},
+			id:      &IdentityDocument{PendingTime: freshPendingTime, AccountID: "999999999999"},
+			wantErr: ErrAccountNotAllowed,
+		},
+		{
+			name:    "region not allowed",
+			v:       &Verifier{expectedRegions: []string{"us-east-1"}},
+			id:      &IdentityDocument{PendingTime: freshPendingTime, Region: "eu-west-1"},
+			wantErr: ErrRegionNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.v.checkPolicy(tt.id)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("checkPolicy() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkPolicy() = %v, want error wrapping %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifierCheckPolicyNonceStore(t *testing.T) {
+	v := &Verifier{nonceStore: &fakeNonceStore{}}
+	id := &IdentityDocument{InstanceID: "i-0123456789abcdef0", PendingTime: time.Now().Format(time.RFC3339)}
+
+	if err := v.checkPolicy(id); err != nil {
+		t.Fatalf("checkPolicy() first call = %v, want nil", err)
+	}
+	if err := v.checkPolicy(id); !errors.Is(err, ErrReplayedDocument) {
+		t.Fatalf("checkPolicy() second call = %v, want error wrapping ErrReplayedDocument", err)
+	}
+}
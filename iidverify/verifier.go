@@ -0,0 +1,167 @@
+package iidverify
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/fullsailor/pkcs7"
+)
+
+// Verifier holds the configuration used to verify instance identity
+// documents. The zero value auto-detects the partition from each
+// document's region and applies no policy, the same as NewVerifier with
+// no options; use NewVerifier to configure anything else.
+type Verifier struct {
+	partition       Partition // "" means auto-detect from each document's region
+	customRSACert   *x509.Certificate
+	customPKCS7Cert *x509.Certificate
+
+	maxAge             time.Duration
+	expectedAccountIDs []string
+	expectedRegions    []string
+	nonceStore         NonceStore
+}
+
+// NewVerifier builds a Verifier. With no options, it auto-detects the
+// correct certificate partition from each document's own region field.
+// Pass WithRegion or WithPartition to pin it to a single partition, or
+// WithCustomCertificate to override the certificates entirely.
+func NewVerifier(opts ...Option) (*Verifier, error) {
+	var cfg VerifierOptions
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	partition := cfg.Partition
+	if partition == "" && cfg.Region != "" {
+		partition = PartitionForRegion(cfg.Region)
+	}
+
+	if partition != "" {
+		if _, ok := parsedCertsByPartition[partition]; !ok {
+			return nil, fmt.Errorf("iidverify: unknown partition %q", partition)
+		}
+	}
+
+	return &Verifier{
+		partition:          partition,
+		customRSACert:      cfg.CustomRSACert,
+		customPKCS7Cert:    cfg.CustomPKCS7Cert,
+		maxAge:             cfg.MaxAge,
+		expectedAccountIDs: cfg.ExpectedAccountIDs,
+		expectedRegions:    cfg.ExpectedRegions,
+		nonceStore:         cfg.NonceStore,
+	}, nil
+}
+
+// certsFor resolves the RSA and PKCS7 certificates to use for a document
+// in the given region, honoring any partition pin or custom certificate
+// override the Verifier was built with.
+func (v *Verifier) certsFor(region string) (rsaCert, pkcs7Cert *x509.Certificate, err error) {
+	if v.partition != "" {
+		certs, ok := parsedCertsByPartition[v.partition]
+		if !ok {
+			return nil, nil, fmt.Errorf("iidverify: no certificate known for partition %q", v.partition)
+		}
+		rsaCert, pkcs7Cert = certs.rsaCert, certs.pkcs7Cert
+	} else {
+		certs, err := certsForRegion(region)
+		if err != nil {
+			return nil, nil, err
+		}
+		rsaCert, pkcs7Cert = certs.rsaCert, certs.pkcs7Cert
+	}
+
+	if v.customRSACert != nil {
+		rsaCert = v.customRSACert
+	}
+	if v.customPKCS7Cert != nil {
+		pkcs7Cert = v.customPKCS7Cert
+	}
+	return rsaCert, pkcs7Cert, nil
+}
+
+// VerifyRSASignature checks sig, the base64-decoded RSA signature from
+// /latest/dynamic/instance-identity/signature, against doc, the raw bytes
+// of /latest/dynamic/instance-identity/document. It returns the parsed
+// document on success.
+func (v *Verifier) VerifyRSASignature(doc, sig []byte) (*IdentityDocument, error) {
+	id, err := parseDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaCert, _, err := v.certsFor(id.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rsaCert.CheckSignature(x509.SHA256WithRSA, doc, sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignatureMismatch, err)
+	}
+
+	if err := v.checkPolicy(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// VerifyPKCS7 checks pkcs7Blob, the contents of
+// /latest/dynamic/instance-identity/pkcs7 (base64, without PEM headers),
+// and returns the identity document it signs on success.
+func (v *Verifier) VerifyPKCS7(pkcs7Blob []byte) (*IdentityDocument, error) {
+	wrapped := fmt.Sprintf("-----BEGIN PKCS7-----\n%s\n-----END PKCS7-----", pkcs7Blob)
+
+	block, rest := pem.Decode([]byte(wrapped))
+	if block == nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: failed to decode PEM-wrapped PKCS7 signature", ErrMalformedDocument)
+	}
+
+	signed, err := pkcs7.Parse(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedDocument, err)
+	}
+
+	id, err := parseDocument(signed.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	_, pkcs7Cert, err := v.certsFor(id.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyPKCS7SignedData(signed, pkcs7Cert); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkPolicy(id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// defaultVerifier backs the package-level VerifyRSASignature and
+// VerifyPKCS7 convenience functions.
+var defaultVerifier = func() *Verifier {
+	v, err := NewVerifier()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}()
+
+// VerifyRSASignature is a convenience wrapper around
+// (*Verifier).VerifyRSASignature using a Verifier built with no options.
+func VerifyRSASignature(doc, sig []byte) (*IdentityDocument, error) {
+	return defaultVerifier.VerifyRSASignature(doc, sig)
+}
+
+// VerifyPKCS7 is a convenience wrapper around (*Verifier).VerifyPKCS7
+// using a Verifier built with no options.
+func VerifyPKCS7(pkcs7Blob []byte) (*IdentityDocument, error) {
+	return defaultVerifier.VerifyPKCS7(pkcs7Blob)
+}